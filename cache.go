@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/vcs"
+)
+
+// noCache disables the shared VCS cache, forcing Install to clone straight
+// into the vendor tree as it did before the cache was introduced.
+var noCache bool
+
+func init() {
+	flag.BoolVar(&noCache, "no-cache", false, "disable the shared VCS cache and clone straight into vendor")
+	subcommands["cache"] = runCacheCommand
+}
+
+// runCacheCommand implements `gapp cache <subcommand>`. The only
+// subcommand today is `clean`, which empties the shared cache.
+func runCacheCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: gapp cache clean")
+	}
+	switch args[0] {
+	case "clean":
+		return CacheClean()
+	default:
+		return fmt.Errorf("unknown cache subcommand %q", args[0])
+	}
+}
+
+// cacheRoot returns the root of the shared VCS cache, honoring
+// $XDG_CACHE_HOME and falling back to ~/.cache when it isn't set.
+func cacheRoot() string {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			home = "."
+		}
+		base = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(base, "gapp", "src")
+}
+
+// CachePath returns the path within the shared cache that the package's
+// repository is, or would be, cloned into. The directory is keyed by a
+// hash of the resolved remote URL so that renamed import paths pointing at
+// the same remote still share a single clone. It resolves the repo root
+// itself rather than relying on p.URL having already been populated by an
+// earlier resolveRepoRoot call, so it's safe to call on its own.
+func (p *Pkg) CachePath() string {
+	url := p.RepoURL()
+	if root, _, err := p.resolveRepoRoot(); err == nil {
+		url = root.URL
+	}
+	sum := sha1.Sum([]byte(url))
+	return filepath.Join(cacheRoot(), hex.EncodeToString(sum[:]))
+}
+
+// cacheRepo resolves the vcs.Repo backed by the shared cache, cloning it in
+// if it isn't already present.
+func (p *Pkg) cacheRepo() (vcs.Repo, error) {
+	root, _, err := p.resolveRepoRoot()
+	if err != nil {
+		return nil, err
+	}
+	cachePath := p.CachePath()
+	var repo vcs.Repo
+	switch root.VCS {
+	case vcs.Git:
+		repo, err = vcs.NewGitRepo(root.URL, cachePath)
+	case vcs.Bzr:
+		repo, err = vcs.NewBzrRepo(root.URL, cachePath)
+	case vcs.Hg:
+		repo, err = vcs.NewHgRepo(root.URL, cachePath)
+	case vcs.Svn:
+		repo, err = vcs.NewSvnRepo(root.URL, cachePath)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !repo.CheckLocal() {
+		Logf("Caching %s", p.Name)
+		if err := repo.Get(); err != nil {
+			return nil, err
+		}
+	}
+	return repo, nil
+}
+
+// installFromCache clones (or reuses) the package's repo in the shared
+// cache, checks it out at the resolved version, links the resulting tree
+// into the vendor path so repeated installs across projects don't
+// re-download the same repo, and returns the concrete reference (commit)
+// that was checked out.
+func (p *Pkg) installFromCache() (string, error) {
+	repo, err := p.cacheRepo()
+	if err != nil {
+		return "", err
+	}
+
+	version := string(p.Version)
+	pinned := p.Reference != ""
+	if pinned {
+		version = p.Reference
+	} else if version != "" {
+		if resolved, rerr := p.resolveVersion(repo, version); rerr == nil && resolved != "" {
+			version = resolved
+		}
+	}
+	if version != "" && !repo.IsReference(version) {
+		if err := repo.UpdateVersion(version); err != nil {
+			return "", err
+		}
+	}
+
+	ref, err := repo.Version()
+	if err != nil {
+		return "", err
+	}
+	if err := linkTree(repo.LocalPath(), p.RepoPath()); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// linkTree populates dst with the contents of src, hard-linking files where
+// possible (cp -al) and falling back to a byte copy across filesystem
+// boundaries, e.g. when the cache and vendor tree live on different mounts.
+func linkTree(src, dst string) error {
+	return filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		if info.IsDir() && info.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, info.Mode())
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		os.Remove(target)
+		if err := os.Link(path, target); err == nil {
+			return nil
+		}
+		return copyFile(path, target, info.Mode())
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// statusRepo resolves the vcs.Repo to read dependency status from. When the
+// shared cache is in use, the vendor copy has no .git of its own (see
+// installFromCache), so the cache clone -- not the vendor tree -- is the
+// repo of record for fetching and enumerating tags.
+func (p *Pkg) statusRepo() (vcs.Repo, error) {
+	if !noCache {
+		return p.cacheRepo()
+	}
+	return p.VCS()
+}
+
+// CacheClean removes the entire shared VCS cache, implementing
+// `gapp cache clean`.
+func CacheClean() error {
+	root := cacheRoot()
+	Logf("Removing cache %s", root)
+	return os.RemoveAll(root)
+}
@@ -0,0 +1,19 @@
+package main
+
+import "fmt"
+
+// subcommands maps a CLI subcommand name (`gapp status`, `gapp cache ...`)
+// to its handler. It's populated by each command's own file via init() so
+// that adding a command doesn't require touching a central switch
+// statement. The main() that parses os.Args and dispatches into this map
+// lives outside this snapshot of the tree.
+var subcommands = map[string]func(args []string) error{}
+
+// runSubcommand looks up and runs a registered subcommand by name.
+func runSubcommand(name string, args []string) error {
+	cmd, ok := subcommands[name]
+	if !ok {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return cmd(args)
+}
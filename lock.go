@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Masterminds/vcs"
+	"gopkg.in/yaml.v2"
+)
+
+// defaultLockFile is the machine-generated companion to vgo.yaml. Where
+// vgo.yaml holds the human-edited constraints (pkg, ver, url), vgo.lock
+// records the fully resolved graph so installs are reproducible.
+const defaultLockFile = "vgo.lock"
+
+// LockedPkg is one dependency's fully resolved entry in vgo.lock: the exact
+// commit, the semver tag it resolved from, the repo it came from, and a
+// hash of the checked-out tree (see hashTree).
+type LockedPkg struct {
+	Name         string       `yaml:"pkg"`
+	Version      Version      `yaml:"ver,omitempty"`
+	Reference    string       `yaml:"ref"`
+	URL          string       `yaml:"url"`
+	VCS          string       `yaml:"vcs"`
+	TreeHash     string       `yaml:"hash"`
+	Dependencies []*LockedPkg `yaml:"deps,omitempty"`
+}
+
+// Lock is the root of vgo.lock.
+type Lock struct {
+	Dependencies []*LockedPkg `yaml:"deps,omitempty"`
+}
+
+// find looks up a dependency by name, recursively.
+func (l *Lock) find(name string) *LockedPkg {
+	return findLocked(l.Dependencies, name)
+}
+
+func findLocked(deps []*LockedPkg, name string) *LockedPkg {
+	for _, d := range deps {
+		if d.Name == name {
+			return d
+		}
+		if found := findLocked(d.Dependencies, name); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// SaveLock writes vgo.lock next to p's manifest, capturing the fully
+// resolved graph: exact commit, resolved tag, repo url, vcs type, and a
+// tree hash per dependency.
+func (p *Pkg) SaveLock() error {
+	lock := &Lock{Dependencies: buildLockTree(p)}
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(p.path, defaultLockFile), data, os.FileMode(0644))
+}
+
+func buildLockTree(p *Pkg) []*LockedPkg {
+	locked := make([]*LockedPkg, 0, len(p.Dependencies))
+	for _, dep := range p.Dependencies {
+		hash, err := hashTree(dep.RepoPath())
+		if err != nil {
+			Logf("Unable to hash %s with error %s", dep.Name, err.Error())
+		}
+		// Resolve via resolveRepoRoot, not the legacy RepoType()/RepoURL()
+		// switch, so custom-domain repos (k8s.io, honnef.co, self-hosted
+		// mirrors) get a real VCS type recorded instead of vcs.NoVCS.
+		url := dep.RepoURL()
+		vcsType := dep.RepoType()
+		if root, _, rerr := dep.resolveRepoRoot(); rerr == nil {
+			url = root.URL
+			vcsType = root.VCS
+		}
+		locked = append(locked, &LockedPkg{
+			Name:         dep.Name,
+			Version:      dep.Version,
+			Reference:    dep.Reference,
+			URL:          url,
+			VCS:          vcsName(vcsType),
+			TreeHash:     hash,
+			Dependencies: buildLockTree(dep),
+		})
+	}
+	return locked
+}
+
+// LoadLock reads vgo.lock next to p's manifest, if present.
+func (p *Pkg) LoadLock() (*Lock, error) {
+	if len(p.lockFile) == 0 {
+		p.lockFile = defaultLockFile
+	}
+	data, err := ioutil.ReadFile(filepath.Join(p.path, p.lockFile))
+	if err != nil {
+		return nil, err
+	}
+	lock := &Lock{}
+	if err := yaml.Unmarshal(data, lock); err != nil {
+		return nil, err
+	}
+	p.hasLock = true
+	p.lock = lock
+	return lock, nil
+}
+
+// applyLock pins dep's Reference and URL from the root's lock, when one
+// names this dependency, so Install can skip version resolution entirely
+// and reproduce the exact graph that was last locked (like `npm ci` /
+// go.sum).
+func (p *Pkg) applyLock() bool {
+	root := p.Root()
+	if !root.hasLock {
+		if _, err := root.LoadLock(); err != nil {
+			return false
+		}
+	}
+	if root.lock == nil {
+		return false
+	}
+	entry := root.lock.find(p.Name)
+	if entry == nil {
+		return false
+	}
+	p.Lock()
+	p.Reference = entry.Reference
+	p.URL = entry.URL
+	p.Unlock()
+	return true
+}
+
+// hashTree computes a single SHA-256 over every file's path and contents
+// under dir, skipping .git, so it changes if anything in the checked-out
+// tree is added, removed, or modified.
+func hashTree(dir string) (string, error) {
+	h := sha256.New()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		io.WriteString(h, rel)
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(h, f)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// vcsName returns the lockfile's string representation of a vcs.Type.
+func vcsName(t vcs.Type) string {
+	return string(t)
+}
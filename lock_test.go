@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v2"
+)
+
+func TestLockRoundTrip(t *testing.T) {
+	lock := &Lock{
+		Dependencies: []*LockedPkg{
+			{
+				Name:      "github.com/foo/bar",
+				Version:   Version("~1.0.0"),
+				Reference: "abc123",
+				URL:       "https://github.com/foo/bar.git",
+				VCS:       "git",
+				TreeHash:  "deadbeef",
+			},
+		},
+	}
+
+	data, err := yaml.Marshal(lock)
+	if err != nil {
+		t.Fatalf("Marshal returned error: %s", err)
+	}
+
+	roundTripped := &Lock{}
+	if err := yaml.Unmarshal(data, roundTripped); err != nil {
+		t.Fatalf("Unmarshal returned error: %s", err)
+	}
+
+	if len(roundTripped.Dependencies) != 1 {
+		t.Fatalf("expected 1 dependency, got %d", len(roundTripped.Dependencies))
+	}
+	got := roundTripped.Dependencies[0]
+	want := lock.Dependencies[0]
+	if got.Name != want.Name || got.Version != want.Version || got.Reference != want.Reference ||
+		got.URL != want.URL || got.VCS != want.VCS || got.TreeHash != want.TreeHash {
+		t.Fatalf("round-tripped entry doesn't match: got %+v, want %+v", got, want)
+	}
+}
+
+func TestHashTreeChangesWithContent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gapp-hashtree")
+	if err != nil {
+		t.Fatalf("TempDir returned error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "a.txt")
+	if err := ioutil.WriteFile(file, []byte("one"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %s", err)
+	}
+	first, err := hashTree(dir)
+	if err != nil {
+		t.Fatalf("hashTree returned error: %s", err)
+	}
+
+	if err := ioutil.WriteFile(file, []byte("two"), 0644); err != nil {
+		t.Fatalf("WriteFile returned error: %s", err)
+	}
+	second, err := hashTree(dir)
+	if err != nil {
+		t.Fatalf("hashTree returned error: %s", err)
+	}
+
+	if first == second {
+		t.Fatal("expected hashTree to change when file content changes")
+	}
+}
+
+func TestLockFind(t *testing.T) {
+	lock := &Lock{
+		Dependencies: []*LockedPkg{
+			{Name: "a", Dependencies: []*LockedPkg{
+				{Name: "b"},
+			}},
+		},
+	}
+
+	if lock.find("b") == nil {
+		t.Fatal("expected to find nested dependency by name")
+	}
+	if lock.find("missing") != nil {
+		t.Fatal("expected nil for unknown name")
+	}
+}
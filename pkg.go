@@ -32,9 +32,14 @@ type Pkg struct {
 
 	meta         *build.Package `yaml:"-"`
 	repo         vcs.Repo       `yaml:"-"`
+	repoOnce     sync.Once      `yaml:"-"`
+	repoErr      error          `yaml:"-"`
 	parent       *Pkg           `yaml:"-"`
 	hasManifest  bool           `yaml:"-"`
 	manifestFile string         `yaml:"-"`
+	hasLock      bool           `yaml:"-"`
+	lockFile     string         `yaml:"-"`
+	lock         *Lock          `yaml:"-"`
 	installed    bool           `yaml:"-"`
 	path         string         `yaml:"-"`
 	installPath  string         `yaml:"-"`
@@ -164,11 +169,18 @@ func (p *Pkg) Init(meta *build.Package) {
 				wg.Done()
 			}()
 		} else {
-			// check the version compatibility. We might need to create a broken diamond here.
+			// Version compatibility across dependents sharing this repo is
+			// reconciled later by Pkg.ResolveVersions, which may surface a
+			// resolver.BrokenDiamondError if no tag satisfies every
+			// constraint.
 		}
 	}
 	wg.Wait()
-	// p.InstallDeps()
+	if p.IsRoot() {
+		if err := p.ResolveVersions(); err != nil {
+			Logf("Unable to resolve dependency versions with error %s", err.Error())
+		}
+	}
 }
 
 // LoadManifest ...
@@ -234,6 +246,30 @@ func (p *Pkg) Install() error {
 		// don't touch the current working directory
 		return nil
 	}
+	p.applyLock()
+
+	if !noCache {
+		ref, err := p.installFromCache()
+		if err != nil {
+			Logf("Failed to install %s from cache with error %s, falling back to a direct clone", p.Name, err.Error())
+		} else {
+			// The vendor copy linkTree produced has no .git of its own (the
+			// cache clone is the repo of record), so it can't be treated as
+			// a live vcs.Repo the way Checkout expects. Replicate just the
+			// post-checkout bookkeeping Checkout would otherwise do.
+			p.Lock()
+			p.path = p.RepoPath()
+			p.installed = true
+			p.Reference = ref
+			p.Unlock()
+			p.LoadManifest()
+			if !p.hasManifest {
+				p.parent.Init(p.parent.Meta())
+			}
+			return nil
+		}
+	}
+
 	repo, err := p.VCS()
 	if repo == nil {
 		return fmt.Errorf("Could not resolve repo for %s with error %s", p.Name, err)
@@ -299,14 +335,30 @@ func (p *Pkg) Checkout() error {
 	if repo.IsDirty() {
 		Logf("Skipping checkout for %s. Dependency is dirty.", p.Name)
 	}
+
 	p.Lock()
 	version := p.Version
-	if p.Reference != "" {
+	pinned := p.Reference != ""
+	if pinned {
 		version = Version(p.Reference)
 	}
+	p.Unlock()
+
+	// Version is a constraint like "~1.0.0" or "1.*", not a ref the vcs repo
+	// knows about, so it has to be solved down to a concrete tag before it
+	// can be passed to UpdateVersion. Pinned References bypass the solver.
+	v := string(version)
+	if !pinned && v != "" {
+		if resolved, rerr := p.resolveVersion(repo, v); rerr == nil && resolved != "" {
+			v = resolved
+		} else if rerr != nil {
+			Logf("Unable to resolve version constraint %q for %s with error %s", v, p.Name, rerr.Error())
+		}
+	}
+
+	p.Lock()
 	p.installed = repo.CheckLocal()
 	if p.installed {
-		v := string(version)
 		if repo.IsReference(v) {
 			Logf("OK %s", p.Name)
 			p.Unlock()
@@ -331,27 +383,31 @@ func (p *Pkg) Checkout() error {
 
 // VCS resolves the vcs.Repo for the Pkg
 func (p *Pkg) VCS() (repo vcs.Repo, err error) {
-	p.Lock()
-	defer p.Unlock()
-	if p.repo != nil {
-		repo = p.repo
-		return
-	}
-	repoType := p.RepoType()
-	repoURL := p.RepoURL()
-	repoPath := p.RepoPath()
-	switch repoType {
-	case vcs.Git:
-		repo, err = vcs.NewGitRepo(repoURL, repoPath)
-	case vcs.Bzr:
-		repo, err = vcs.NewBzrRepo(repoURL, repoPath)
-	case vcs.Hg:
-		repo, err = vcs.NewHgRepo(repoURL, repoPath)
-	case vcs.Svn:
-		repo, err = vcs.NewSvnRepo(repoURL, repoPath)
-	}
-	p.repo = repo
-	return
+	// repoOnce, rather than the double-checked p.Lock()/p.Unlock() this
+	// replaced, makes sure concurrent first callers don't each resolve the
+	// repo root and construct their own vcs.Repo, discarding all but one:
+	// resolveRepoRoot takes p's lock itself (and may do network I/O), so it
+	// can't be called while p's lock is held, which is exactly the gap two
+	// callers could both observe p.repo == nil through.
+	p.repoOnce.Do(func() {
+		root, _, rerr := p.resolveRepoRoot()
+		if rerr != nil {
+			p.repoErr = rerr
+			return
+		}
+		repoPath := p.RepoPath()
+		switch root.VCS {
+		case vcs.Git:
+			p.repo, p.repoErr = vcs.NewGitRepo(root.URL, repoPath)
+		case vcs.Bzr:
+			p.repo, p.repoErr = vcs.NewBzrRepo(root.URL, repoPath)
+		case vcs.Hg:
+			p.repo, p.repoErr = vcs.NewHgRepo(root.URL, repoPath)
+		case vcs.Svn:
+			p.repo, p.repoErr = vcs.NewSvnRepo(root.URL, repoPath)
+		}
+	})
+	return p.repo, p.repoErr
 }
 
 // RepoURL creates the repo url from the package import path
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// Concurrent first callers of VCS() used to each observe p.repo == nil
+// between the unlock before resolveRepoRoot and the relock after
+// constructing the vcs.Repo, so more than one could resolve the repo root
+// and build its own *vcs.Repo, discarding all but one. repoOnce should make
+// exactly one of them do the work and the rest share its result.
+func TestVCSConstructsRepoOnlyOnceUnderConcurrentCallers(t *testing.T) {
+	stub := &stubRepoRootResolver{root: &RepoRoot{Root: "github.com/whitecypher/gapp", URL: "https://github.com/whitecypher/gapp", VCS: "git"}}
+	withStubRepoRootResolver(t, stub)
+
+	p := NewPkg("github.com/whitecypher/gapp")
+
+	const n = 20
+	var wg sync.WaitGroup
+	results := make([]interface{}, n)
+	wg.Add(n)
+	for i := 0; i < n; i++ {
+		go func(i int) {
+			defer wg.Done()
+			repo, err := p.VCS()
+			if err != nil {
+				t.Errorf("unexpected error: %s", err)
+				return
+			}
+			results[i] = repo
+		}(i)
+	}
+	wg.Wait()
+
+	if stub.calls != 1 {
+		t.Fatalf("expected resolveRepoRoot to run exactly once across concurrent callers, got %d calls", stub.calls)
+	}
+	first := results[0]
+	for i, r := range results {
+		if r != first {
+			t.Fatalf("expected every caller to share the same *vcs.Repo, caller %d got a different one", i)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/Masterminds/vcs"
+	xvcs "golang.org/x/tools/go/vcs"
+)
+
+// RepoRoot describes the result of resolving an import path down to its
+// version control root, mirroring the information `go get` relies on.
+type RepoRoot struct {
+	Root string   // import path of the repository root, e.g. "k8s.io/client-go"
+	URL  string   // repository remote url
+	VCS  vcs.Type // resolved vcs type
+}
+
+// RepoRootResolver resolves an import path to its RepoRoot. It exists so the
+// network-backed resolution can be stubbed out in tests.
+type RepoRootResolver interface {
+	RepoRoot(importPath string) (*RepoRoot, error)
+}
+
+// repoRootResolver is the resolver used by Pkg.resolveRepoRoot. Tests may
+// swap this for a stub to avoid hitting the network.
+var repoRootResolver RepoRootResolver = &vcsRepoRootResolver{}
+
+// vcsRepoRootResolver resolves import paths using golang.org/x/tools/go/vcs,
+// the same package `go get`, glide, and gb-vendor use to follow the
+// `<meta name="go-import">` discovery protocol.
+type vcsRepoRootResolver struct{}
+
+func (r *vcsRepoRootResolver) RepoRoot(importPath string) (*RepoRoot, error) {
+	root, err := xvcs.RepoRootForImportPath(importPath, false)
+	if err != nil {
+		return nil, err
+	}
+	return &RepoRoot{
+		Root: root.Root,
+		URL:  root.Repo,
+		VCS:  vcsType(root.VCS.Cmd),
+	}, nil
+}
+
+// vcsType maps an x/tools/go/vcs command name to the equivalent
+// Masterminds/vcs type.
+func vcsType(cmd string) vcs.Type {
+	switch cmd {
+	case "git":
+		return vcs.Git
+	case "hg":
+		return vcs.Hg
+	case "bzr":
+		return vcs.Bzr
+	case "svn":
+		return vcs.Svn
+	}
+	return vcs.NoVCS
+}
+
+// repoRootCache caches resolved repo roots so that sibling subpackages of
+// the same repository don't each trigger their own HTTP discovery request.
+// repoRootByName is the fast path, keyed by the exact import path already
+// resolved; repoRoots holds the distinct roots discovered so far for prefix
+// matching against import paths not seen verbatim yet (e.g. a second
+// subpackage of a repo whose first subpackage resolved it).
+var (
+	repoRootMu     sync.Mutex
+	repoRoots      []*RepoRoot
+	repoRootByName sync.Map // map[string]*RepoRoot
+)
+
+// lookupRepoRoot finds the cached RepoRoot for importPath, either because
+// it was resolved verbatim before or because it falls under an
+// already-known repo root.
+func lookupRepoRoot(importPath string) *RepoRoot {
+	if cached, ok := repoRootByName.Load(importPath); ok {
+		return cached.(*RepoRoot)
+	}
+	repoRootMu.Lock()
+	defer repoRootMu.Unlock()
+	for _, root := range repoRoots {
+		if importPath == root.Root || strings.HasPrefix(importPath, root.Root+"/") {
+			return root
+		}
+	}
+	return nil
+}
+
+// storeRepoRoot records root as resolved for importPath, and as a known
+// repo root for subsequent prefix lookups of its subpackages.
+func storeRepoRoot(importPath string, root *RepoRoot) {
+	repoRootByName.Store(importPath, root)
+	repoRootMu.Lock()
+	defer repoRootMu.Unlock()
+	for _, existing := range repoRoots {
+		if existing.Root == root.Root {
+			return
+		}
+	}
+	repoRoots = append(repoRoots, root)
+}
+
+// resolveRepoRoot resolves p.Name to its repository root using
+// repoRootResolver, caching the result per import root. It populates p.URL
+// and returns the vcs type and the sub-path of p relative to the repo root
+// (empty when p.Name is itself the root). When the resolver fails (e.g. the
+// network is unavailable) it falls back to the existing hard-coded
+// host rules.
+func (p *Pkg) resolveRepoRoot() (root *RepoRoot, subPath string, err error) {
+	root = lookupRepoRoot(p.Name)
+	if root == nil {
+		root, err = repoRootResolver.RepoRoot(p.Name)
+		if err != nil {
+			return p.fallbackRepoRoot()
+		}
+		storeRepoRoot(p.Name, root)
+	}
+	subPath = strings.TrimPrefix(p.Name, root.Root)
+	subPath = strings.TrimPrefix(subPath, "/")
+	p.Lock()
+	p.URL = root.URL
+	p.Unlock()
+	return root, subPath, nil
+}
+
+// fallbackRepoRoot reconstructs a RepoRoot from the hard-coded RepoURL/
+// RepoType rules, used when resolveRepoRoot can't reach the network.
+func (p *Pkg) fallbackRepoRoot() (*RepoRoot, string, error) {
+	root := &RepoRoot{
+		Root: p.Name,
+		URL:  p.RepoURL(),
+		VCS:  p.RepoType(),
+	}
+	p.Lock()
+	p.URL = root.URL
+	p.Unlock()
+	return root, "", nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/Masterminds/vcs"
+)
+
+// stubRepoRootResolver is a RepoRootResolver that returns a fixed result
+// without touching the network, counting how many times it's invoked.
+type stubRepoRootResolver struct {
+	calls int
+	root  *RepoRoot
+	err   error
+}
+
+func (s *stubRepoRootResolver) RepoRoot(importPath string) (*RepoRoot, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.root, nil
+}
+
+func withStubRepoRootResolver(t *testing.T, stub *stubRepoRootResolver) {
+	origResolver := repoRootResolver
+	origRoots := repoRoots
+	t.Cleanup(func() {
+		repoRootResolver = origResolver
+		repoRootByName = sync.Map{}
+		repoRoots = origRoots
+	})
+	repoRootResolver = stub
+	repoRootByName = sync.Map{}
+	repoRoots = nil
+}
+
+func TestResolveRepoRootPopulatesURLAndSubPath(t *testing.T) {
+	stub := &stubRepoRootResolver{root: &RepoRoot{Root: "k8s.io/client-go", URL: "https://github.com/kubernetes/client-go"}}
+	withStubRepoRootResolver(t, stub)
+
+	p := NewPkg("k8s.io/client-go/kubernetes")
+	root, subPath, err := p.resolveRepoRoot()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if root.Root != "k8s.io/client-go" {
+		t.Fatalf("expected root %q, got %q", "k8s.io/client-go", root.Root)
+	}
+	if subPath != "kubernetes" {
+		t.Fatalf("expected subPath %q, got %q", "kubernetes", subPath)
+	}
+	if p.URL != stub.root.URL {
+		t.Fatalf("expected p.URL to be populated with %q, got %q", stub.root.URL, p.URL)
+	}
+}
+
+func TestResolveRepoRootCachesSiblingSubpackages(t *testing.T) {
+	stub := &stubRepoRootResolver{root: &RepoRoot{Root: "k8s.io/client-go", URL: "https://github.com/kubernetes/client-go"}}
+	withStubRepoRootResolver(t, stub)
+
+	if _, _, err := NewPkg("k8s.io/client-go/rest").resolveRepoRoot(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, _, err := NewPkg("k8s.io/client-go/tools/cache").resolveRepoRoot(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if stub.calls != 1 {
+		t.Fatalf("expected the resolver to be hit once and the second subpackage to share the cached root, got %d calls", stub.calls)
+	}
+}
+
+func TestResolveRepoRootFallsBackWhenResolverFails(t *testing.T) {
+	stub := &stubRepoRootResolver{err: errors.New("network unavailable")}
+	withStubRepoRootResolver(t, stub)
+
+	p := NewPkg("github.com/whitecypher/gapp")
+	root, subPath, err := p.resolveRepoRoot()
+	if err != nil {
+		t.Fatalf("fallback should not surface an error, got %s", err)
+	}
+	if subPath != "" {
+		t.Fatalf("expected empty subPath from the fallback, got %q", subPath)
+	}
+	if root.VCS == vcs.NoVCS {
+		t.Fatalf("expected the hard-coded github.com fallback to resolve a vcs type")
+	}
+}
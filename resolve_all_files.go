@@ -0,0 +1,169 @@
+package main
+
+import (
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/whitecypher/vgo/lib/native"
+)
+
+// resolveTestImports controls whether ResolveAllFiles also walks the
+// imports of _test.go files when unioning a package's dependencies.
+var resolveTestImports bool
+
+// resolveAllFilesVisited tracks import paths already walked by
+// ResolveAllFiles so that import cycles and diamond-shaped graphs are only
+// visited once. It's keyed by the literal import path rather than repo
+// root: two subpackages of the same repo (e.g. github.com/dep/pkga and
+// github.com/dep/pkgb) live in different directories with different
+// imports of their own, so both must be walked even though they share a
+// repo root. It's a sync.Map rather than a plain map because
+// ResolveAllFiles walks sibling imports concurrently.
+var resolveAllFilesVisited sync.Map // map[string]bool
+
+// ResolveAllFiles walks every .go file under p's package directory with
+// go/parser instead of relying on build.Import, which silently skips files
+// excluded by build tags, test files, and files that don't compile for the
+// current GOOS/GOARCH. This catches cross-platform dependencies that would
+// otherwise be dropped from the manifest. Imports are unioned across every
+// file regardless of its +build constraints; when resolveTestImports is
+// set, _test.go files are included too. Results are deduped by repo root.
+func (p *Pkg) ResolveAllFiles() ([]string, error) {
+	resolveAllFilesVisited = sync.Map{}
+	return p.resolveAllFilesRecursive()
+}
+
+func (p *Pkg) resolveAllFilesRecursive() ([]string, error) {
+	dir := p.path
+	if dir == "" {
+		m := p.Meta()
+		if m != nil {
+			dir = m.Dir
+		}
+	}
+	if dir == "" {
+		return nil, nil
+	}
+
+	imports, err := importsInDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	roots := []string{}
+	wg := sync.WaitGroup{}
+	mu := sync.Mutex{}
+	var firstErr error
+
+	for _, imp := range imports {
+		if native.IsNative(imp) {
+			continue
+		}
+		if strings.HasPrefix(imp, p.Name) {
+			continue
+		}
+
+		dep := NewPkg(imp)
+		dep.parent = p
+		root, _, rerr := dep.resolveRepoRoot()
+		repoRoot := imp
+		if rerr == nil {
+			repoRoot = root.Root
+		}
+
+		if _, loaded := resolveAllFilesVisited.LoadOrStore(imp, true); loaded {
+			mu.Lock()
+			if !seen[repoRoot] {
+				seen[repoRoot] = true
+				roots = append(roots, repoRoot)
+			}
+			mu.Unlock()
+			continue
+		}
+
+		mu.Lock()
+		if !seen[repoRoot] {
+			seen[repoRoot] = true
+			roots = append(roots, repoRoot)
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(dep *Pkg) {
+			defer wg.Done()
+			if m, err := build.Import(dep.Name, cwd, build.FindOnly); err == nil {
+				dep.path = m.Dir
+			}
+			sub, err := dep.resolveAllFilesRecursive()
+			if err != nil {
+				mu.Lock()
+				if firstErr == nil {
+					firstErr = err
+				}
+				mu.Unlock()
+				return
+			}
+			mu.Lock()
+			for _, r := range sub {
+				if !seen[r] {
+					seen[r] = true
+					roots = append(roots, r)
+				}
+			}
+			mu.Unlock()
+		}(dep)
+	}
+	wg.Wait()
+
+	sort.Strings(roots)
+	return roots, firstErr
+}
+
+// importsInDir parses every .go file directly within dir with
+// parser.ImportsOnly and returns the union of their import paths. Unlike
+// build.Import it does not evaluate +build constraints or GOOS/GOARCH
+// filename suffixes, so files excluded on this platform still contribute
+// their imports.
+func importsInDir(dir string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	fset := token.NewFileSet()
+	seen := map[string]bool{}
+	imports := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		if strings.HasSuffix(name, "_test.go") && !resolveTestImports {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			// Skip files that don't parse; ResolveAllFiles best-effort unions
+			// what it can read rather than failing the whole walk.
+			continue
+		}
+		for _, imp := range file.Imports {
+			path := strings.Trim(imp.Path.Value, `"`)
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+			imports = append(imports, path)
+		}
+	}
+	sort.Strings(imports)
+	return imports, nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"go/build"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRepoRootResolver resolves every import path under github.com/dep to
+// the single repo root github.com/dep, mirroring a real multi-package repo
+// such as github.com/dep/pkga and github.com/dep/pkgb sharing one root.
+type fakeRepoRootResolver struct{}
+
+func (f *fakeRepoRootResolver) RepoRoot(importPath string) (*RepoRoot, error) {
+	if strings.HasPrefix(importPath, "github.com/dep") {
+		return &RepoRoot{Root: "github.com/dep", URL: "https://github.com/dep", VCS: "git"}, nil
+	}
+	return &RepoRoot{Root: importPath, URL: "https://" + importPath, VCS: "git"}, nil
+}
+
+// writeGoFile writes a minimal package file importing each of imports,
+// creating dir if needed.
+func writeGoFile(t *testing.T, dir, pkg string, imports ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %s", dir, err)
+	}
+	var b strings.Builder
+	b.WriteString("package " + pkg + "\n\n")
+	for _, imp := range imports {
+		b.WriteString("import _ \"" + imp + "\"\n")
+	}
+	file := filepath.Join(dir, "file.go")
+	if err := ioutil.WriteFile(file, []byte(b.String()), 0644); err != nil {
+		t.Fatalf("failed to write %s: %s", file, err)
+	}
+}
+
+// Two subpackages of the same repo root (github.com/dep/pkga and
+// github.com/dep/pkgb) each import a distinct leaf dependency. Deduping the
+// walk by repo root rather than by import path used to make the second
+// subpackage's own imports vanish entirely, since only the first one ever
+// got its directory parsed.
+func TestResolveAllFilesWalksEverySiblingSubpackage(t *testing.T) {
+	origResolver := repoRootResolver
+	origRoots := repoRoots
+	origGOPATH := build.Default.GOPATH
+	t.Cleanup(func() {
+		repoRootResolver = origResolver
+		repoRoots = origRoots
+		repoRootByName = sync.Map{}
+		build.Default.GOPATH = origGOPATH
+	})
+	repoRootResolver = &fakeRepoRootResolver{}
+	repoRootByName = sync.Map{}
+	repoRoots = nil
+
+	gopathRoot := t.TempDir()
+	build.Default.GOPATH = gopathRoot
+
+	appDir := filepath.Join(gopathRoot, "app")
+	writeGoFile(t, appDir, "app", "github.com/dep/pkga", "github.com/dep/pkgb")
+	writeGoFile(t, filepath.Join(gopathRoot, "src", "github.com", "dep", "pkga"), "pkga", "github.com/onlyviaa/thing")
+	writeGoFile(t, filepath.Join(gopathRoot, "src", "github.com", "dep", "pkgb"), "pkgb", "github.com/onlyviab/thing")
+
+	p := NewPkg("github.com/app")
+	p.path = appDir
+
+	roots, err := p.ResolveAllFiles()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	hasA, hasB := false, false
+	for _, r := range roots {
+		if r == "github.com/onlyviaa/thing" {
+			hasA = true
+		}
+		if r == "github.com/onlyviab/thing" {
+			hasB = true
+		}
+	}
+	if !hasA || !hasB {
+		t.Fatalf("expected both sibling subpackages' imports to be walked, got %v", roots)
+	}
+}
@@ -0,0 +1,190 @@
+// Package resolver implements a semver-aware version constraint solver for
+// gapp's dependency graph. It enumerates the tags available for each
+// dependency's repository and, in a single MVS-style pass, picks the
+// highest tag that satisfies every constraint contributed by the packages
+// that depend on it.
+package resolver
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver"
+)
+
+// Repo is the subset of vcs.Repo the solver needs to enumerate tags and
+// move a checkout to a resolved version. vcs.Repo already satisfies this.
+type Repo interface {
+	Tags() ([]string, error)
+	UpdateVersion(string) error
+}
+
+// Node is one dependency's contribution to the graph: the repository it
+// resolves to, the constraint it places on that repository's version (or a
+// pinned Reference that bypasses the solver), and the name of the package
+// that contributed the constraint, used for "broken diamond" reporting.
+type Node struct {
+	RepoRoot   string // import path of the repository root
+	Constraint string // e.g. "~1.0.0", "1.*", "^1.2.3", ">=1.2 <2"
+	Reference  string // pinned commit/tag; bypasses the solver when set
+	Parent     string // name of the Pkg that contributed this node
+	Repo       Repo
+}
+
+// Resolution is the chosen version for a repository root, ready to be
+// written back to the owning Pkg's Reference field.
+type Resolution struct {
+	RepoRoot string
+	Version  string // resolved tag, or the pinned reference verbatim
+}
+
+// BrokenDiamondError reports that two or more dependents impose constraints
+// on the same repository that no single tag can satisfy.
+type BrokenDiamondError struct {
+	RepoRoot    string
+	Constraints []string // "parent requires constraint"
+}
+
+func (e *BrokenDiamondError) Error() string {
+	return fmt.Sprintf("broken diamond: no version of %s satisfies all of: %s", e.RepoRoot, strings.Join(e.Constraints, "; "))
+}
+
+// MultiError aggregates the per-repo-root errors from a Resolve call that
+// partially succeeded, so a broken diamond (or bad tag list) on one repo
+// doesn't hide resolutions that succeeded for every other repo root.
+type MultiError []error
+
+func (e MultiError) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Resolve runs one pass collecting {repoRoot -> constraints} from nodes,
+// then a second pass picking the highest tag satisfying the union of
+// constraints for each repo root. It does not call UpdateVersion; moving a
+// Repo to a returned Resolution is done separately, e.g. by Checkout.
+// Nodes with a pinned Reference bypass tag enumeration entirely but are
+// still returned so the caller can record them in the lockfile.
+//
+// A repo root that fails to resolve (e.g. a genuine broken diamond) does
+// not abort the rest of the pass: Resolve keeps going and returns the
+// resolutions it did manage alongside a MultiError describing the
+// failures, so callers can apply the former and just log/report the
+// latter.
+func Resolve(nodes []Node) ([]Resolution, error) {
+	groups := map[string][]Node{}
+	var order []string
+	for _, n := range nodes {
+		if _, ok := groups[n.RepoRoot]; !ok {
+			order = append(order, n.RepoRoot)
+		}
+		groups[n.RepoRoot] = append(groups[n.RepoRoot], n)
+	}
+
+	results := make([]Resolution, 0, len(order))
+	var errs MultiError
+	for _, repoRoot := range order {
+		group := groups[repoRoot]
+		res, err := resolveGroup(repoRoot, group)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if res == nil {
+			// Nothing to pin: no dependent constrains this repo and it has
+			// no semver tags to default to. Leave it alone rather than
+			// treating the absence of a constraint as a conflict.
+			continue
+		}
+		results = append(results, *res)
+	}
+	if len(errs) > 0 {
+		return results, errs
+	}
+	return results, nil
+}
+
+// resolveGroup picks the version for one repo root, or returns a nil
+// Resolution (with a nil error) when there's nothing to pin: no dependent
+// places a constraint on it and it has no semver tags to default to.
+func resolveGroup(repoRoot string, group []Node) (*Resolution, error) {
+	for _, n := range group {
+		if n.Reference != "" {
+			return &Resolution{RepoRoot: repoRoot, Version: n.Reference}, nil
+		}
+	}
+
+	constraints := make([]*semver.Constraints, 0, len(group))
+	labels := make([]string, 0, len(group))
+	var repo Repo
+	for _, n := range group {
+		if n.Repo != nil {
+			repo = n.Repo
+		}
+		if n.Constraint == "" {
+			continue
+		}
+		c, err := semver.NewConstraint(n.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q from %s: %s", n.Constraint, n.Parent, err)
+		}
+		constraints = append(constraints, c)
+		labels = append(labels, fmt.Sprintf("%s requires %s", n.Parent, n.Constraint))
+	}
+	if repo == nil {
+		return nil, fmt.Errorf("no repo available to enumerate tags for %s", repoRoot)
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list tags for %s: %s", repoRoot, err)
+	}
+
+	versions := make([]*semver.Version, 0, len(tags))
+	byVersion := map[*semver.Version]string{}
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			// Not every tag is necessarily a semver tag, skip the ones that aren't.
+			continue
+		}
+		versions = append(versions, v)
+		byVersion[v] = tag
+	}
+	sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+	for _, v := range versions {
+		if satisfiesAll(v, constraints) {
+			return &Resolution{RepoRoot: repoRoot, Version: byVersion[v]}, nil
+		}
+	}
+
+	if len(constraints) == 0 {
+		// No constraint was ever placed on this repo root (the common case
+		// for an auto-discovered, never-pinned dependency) and it has no
+		// semver tags to pick a default from. That's not a conflict.
+		return nil, nil
+	}
+
+	return nil, &BrokenDiamondError{RepoRoot: repoRoot, Constraints: labels}
+}
+
+func satisfiesAll(v *semver.Version, constraints []*semver.Constraints) bool {
+	for _, c := range constraints {
+		if !c.Check(v) {
+			return false
+		}
+	}
+	return true
+}
+
+// Checkout moves repo to the resolved version by calling UpdateVersion.
+// Pinned references are expected to already be verbatim commit SHAs/tags
+// and are passed through unchanged.
+func Checkout(repo Repo, resolution Resolution) error {
+	return repo.UpdateVersion(resolution.Version)
+}
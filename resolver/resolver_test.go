@@ -0,0 +1,123 @@
+package resolver
+
+import "testing"
+
+// stubRepo is a fake Repo that serves a fixed list of tags and records the
+// version passed to UpdateVersion.
+type stubRepo struct {
+	tags    []string
+	updated string
+}
+
+func (r *stubRepo) Tags() ([]string, error) { return r.tags, nil }
+
+func (r *stubRepo) UpdateVersion(v string) error {
+	r.updated = v
+	return nil
+}
+
+func TestResolvePicksHighestSatisfyingTag(t *testing.T) {
+	repo := &stubRepo{tags: []string{"v0.9.0", "v1.0.0", "v1.2.3", "v2.0.0"}}
+	nodes := []Node{
+		{RepoRoot: "example.com/foo", Constraint: "~1.0.0", Parent: "a", Repo: repo},
+	}
+
+	resolutions, err := Resolve(nodes)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %s", err)
+	}
+	if len(resolutions) != 1 || resolutions[0].Version != "v1.0.0" {
+		t.Fatalf("expected v1.0.0, got %+v", resolutions)
+	}
+}
+
+func TestResolveIsDeterministic(t *testing.T) {
+	repo := &stubRepo{tags: []string{"v1.0.0", "v1.1.0", "v1.2.0"}}
+	nodes := []Node{
+		{RepoRoot: "example.com/foo", Constraint: ">=1.0 <2", Parent: "a", Repo: repo},
+	}
+
+	first, err := Resolve(nodes)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %s", err)
+	}
+	second, err := Resolve(nodes)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %s", err)
+	}
+	if first[0].Version != second[0].Version {
+		t.Fatalf("expected deterministic result, got %s then %s", first[0].Version, second[0].Version)
+	}
+}
+
+func TestResolvePinnedReferenceBypassesSolver(t *testing.T) {
+	repo := &stubRepo{tags: []string{"v1.0.0"}}
+	nodes := []Node{
+		{RepoRoot: "example.com/foo", Constraint: "~1.0.0", Reference: "deadbeef", Parent: "a", Repo: repo},
+	}
+
+	resolutions, err := Resolve(nodes)
+	if err != nil {
+		t.Fatalf("Resolve returned error: %s", err)
+	}
+	if resolutions[0].Version != "deadbeef" {
+		t.Fatalf("expected pinned reference to bypass the solver, got %s", resolutions[0].Version)
+	}
+}
+
+func TestResolveBrokenDiamond(t *testing.T) {
+	repo := &stubRepo{tags: []string{"v1.0.0", "v2.0.0"}}
+	nodes := []Node{
+		{RepoRoot: "example.com/foo", Constraint: "~1.0.0", Parent: "a", Repo: repo},
+		{RepoRoot: "example.com/foo", Constraint: "~2.0.0", Parent: "b", Repo: repo},
+	}
+
+	_, err := Resolve(nodes)
+	if err == nil {
+		t.Fatal("expected a broken diamond error")
+	}
+	errs, ok := err.(MultiError)
+	if !ok || len(errs) != 1 {
+		t.Fatalf("expected a MultiError with one entry, got %T: %s", err, err)
+	}
+	if _, ok := errs[0].(*BrokenDiamondError); !ok {
+		t.Fatalf("expected *BrokenDiamondError, got %T: %s", errs[0], errs[0])
+	}
+}
+
+// An auto-discovered dependency that was never pinned and whose repo has no
+// (parseable) tags is the common case, not a conflict: Resolve should skip
+// it rather than reporting a broken diamond.
+func TestResolveSkipsUntaggedUnconstrainedRepo(t *testing.T) {
+	repo := &stubRepo{tags: []string{}}
+	nodes := []Node{
+		{RepoRoot: "example.com/untagged", Constraint: "", Parent: "a", Repo: repo},
+	}
+
+	resolutions, err := Resolve(nodes)
+	if err != nil {
+		t.Fatalf("expected no error for an untagged, unconstrained repo, got %s", err)
+	}
+	if len(resolutions) != 0 {
+		t.Fatalf("expected no resolution to be produced, got %+v", resolutions)
+	}
+}
+
+// One repo root failing to resolve must not prevent every other repo root
+// in the same graph from resolving.
+func TestResolvePartialFailureStillResolvesOthers(t *testing.T) {
+	conflicting := &stubRepo{tags: []string{"v1.0.0"}}
+	ok := &stubRepo{tags: []string{"v1.0.0", "v1.5.0"}}
+	nodes := []Node{
+		{RepoRoot: "example.com/conflicting", Constraint: "~2.0.0", Parent: "a", Repo: conflicting},
+		{RepoRoot: "example.com/ok", Constraint: "~1.0.0", Parent: "a", Repo: ok},
+	}
+
+	resolutions, err := Resolve(nodes)
+	if errs, isMulti := err.(MultiError); !isMulti || len(errs) != 1 {
+		t.Fatalf("expected a MultiError with one entry for example.com/conflicting, got %T: %v", err, err)
+	}
+	if len(resolutions) != 1 || resolutions[0].RepoRoot != "example.com/ok" || resolutions[0].Version != "v1.0.0" {
+		t.Fatalf("expected only example.com/ok to resolve, got %+v", resolutions)
+	}
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Masterminds/semver"
+	"github.com/Masterminds/vcs"
+)
+
+func init() {
+	subcommands["status"] = runStatusSubcommand
+}
+
+// runStatusSubcommand implements `gapp status [-json]` against the project
+// rooted at the current working directory.
+func runStatusSubcommand(args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "emit status as JSON instead of a table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	root := NewPkg("")
+	if err := root.LoadManifest(); err != nil {
+		return err
+	}
+	return RunStatusCommand(root, *asJSON)
+}
+
+// DependencyStatus reports how far a vendored dependency has drifted from
+// upstream, both in raw commits and in terms of the manifest's Version
+// constraint.
+type DependencyStatus struct {
+	Name                string `json:"name"`
+	Reference           string `json:"reference"`
+	LatestTag           string `json:"latestTag"`
+	CommitsBehind       int    `json:"commitsBehind"` // -1 when unknown for this vcs type
+	SatisfiesConstraint bool   `json:"satisfiesConstraint"`
+}
+
+// Status reports the out-of-date-ness of each direct dependency of p
+// (nested dependencies are not visited): the vendored ref, the highest tag
+// satisfying the manifest's Version constraint (the highest tag overall when
+// there is no constraint), how many commits the vendored ref is behind the
+// default branch, and whether any tag satisfies that constraint. Tags are
+// not compared with the vendored ref, so LatestTag may be the tag already
+// vendored or an older one.
+func (p *Pkg) Status() ([]DependencyStatus, error) {
+	statuses := make([]DependencyStatus, 0, len(p.Dependencies))
+	for _, dep := range p.Dependencies {
+		s, err := dep.status()
+		if err != nil {
+			Logf("Unable to determine status for %s with error %s", dep.Name, err.Error())
+			continue
+		}
+		statuses = append(statuses, s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+func (p *Pkg) status() (DependencyStatus, error) {
+	s := DependencyStatus{Name: p.Name, Reference: p.Reference}
+
+	repo, err := p.statusRepo()
+	if err != nil {
+		return s, err
+	}
+	if err := repo.Update(); err != nil {
+		Logf("Unable to fetch %s with error %s", p.Name, err.Error())
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return s, err
+	}
+	s.LatestTag = latestSatisfyingTag(tags, string(p.Version))
+	s.SatisfiesConstraint = s.LatestTag != ""
+
+	s.CommitsBehind = commitsBehind(repo)
+	return s, nil
+}
+
+// latestSatisfyingTag returns the highest semver tag satisfying constraint,
+// or the highest tag overall when constraint is empty.
+func latestSatisfyingTag(tags []string, constraint string) string {
+	var c *semver.Constraints
+	if constraint != "" {
+		parsed, err := semver.NewConstraint(constraint)
+		if err == nil {
+			c = parsed
+		}
+	}
+
+	var best *semver.Version
+	bestTag := ""
+	for _, tag := range tags {
+		v, err := semver.NewVersion(tag)
+		if err != nil {
+			continue
+		}
+		if c != nil && !c.Check(v) {
+			continue
+		}
+		if best == nil || v.GreaterThan(best) {
+			best = v
+			bestTag = tag
+		}
+	}
+	return bestTag
+}
+
+// commitsBehind reports how many commits the repo's current checkout is
+// behind its default remote branch. It's driven off Masterminds/vcs rather
+// than a host API (GitHub, GitLab, ...) so it works for any remote,
+// including self-hosted ones, but only git is currently supported; other
+// vcs types report -1.
+//
+// Dependencies are checked out to a pinned tag or commit by UpdateVersion,
+// which leaves them in detached-HEAD state with no tracking branch, so
+// diffing against @{upstream} would always fail here. Instead the remote's
+// default branch is resolved explicitly and used as the comparison point.
+func commitsBehind(repo vcs.Repo) int {
+	if repo.Vcs() != vcs.Git {
+		return -1
+	}
+	branch, err := defaultRemoteBranch(repo)
+	if err != nil {
+		return -1
+	}
+	out, err := repo.RunFromDir("git", "rev-list", "--count", "HEAD.."+branch)
+	if err != nil {
+		return -1
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// defaultRemoteBranch resolves the remote "origin"'s default branch as a
+// ref git can diff against directly (e.g. "origin/main"). It first asks the
+// local remote-tracking symref, set up by `git clone`/`git remote set-head`,
+// falling back to asking the remote itself when that symref is missing, as
+// can happen for a repo cloned shallowly or cached before it was added.
+func defaultRemoteBranch(repo vcs.Repo) (string, error) {
+	if out, err := repo.RunFromDir("git", "symbolic-ref", "refs/remotes/origin/HEAD"); err == nil {
+		ref := strings.TrimSpace(string(out))
+		if name := strings.TrimPrefix(ref, "refs/remotes/"); name != ref {
+			return name, nil
+		}
+	}
+
+	out, err := repo.RunFromDir("git", "remote", "show", "origin")
+	if err != nil {
+		return "", err
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if branch := strings.TrimPrefix(line, "HEAD branch:"); branch != line {
+			return "origin/" + strings.TrimSpace(branch), nil
+		}
+	}
+	return "", fmt.Errorf("unable to determine origin's default branch")
+}
+
+// RunStatusCommand implements `gapp status`. With asJSON set it emits the
+// dependency statuses as a JSON array for tooling; otherwise it prints the
+// table a human reads at the terminal.
+func RunStatusCommand(p *Pkg, asJSON bool) error {
+	statuses, err := p.Status()
+	if err != nil {
+		return err
+	}
+	if asJSON {
+		return json.NewEncoder(os.Stdout).Encode(statuses)
+	}
+	printStatusTable(statuses)
+	return nil
+}
+
+func printStatusTable(statuses []DependencyStatus) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCURRENT\tLATEST TAG\tBEHIND\tSATISFIES")
+	for _, s := range statuses {
+		behind := "?"
+		if s.CommitsBehind >= 0 {
+			behind = strconv.Itoa(s.CommitsBehind)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", s.Name, s.Reference, s.LatestTag, behind, s.SatisfiesConstraint)
+	}
+	w.Flush()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Masterminds/vcs"
+)
+
+// stubVCSRepo is a fake vcs.Repo that only implements the behaviour
+// commitsBehind/defaultRemoteBranch actually exercise; every other method is
+// a zero-value stand-in to satisfy the interface.
+type stubVCSRepo struct {
+	vcsType  vcs.Type
+	commands map[string]string // "git symbolic-ref refs/remotes/origin/HEAD" -> output
+	errs     map[string]error
+}
+
+func (s *stubVCSRepo) Get() error                 { return nil }
+func (s *stubVCSRepo) CheckLocal() bool           { return true }
+func (s *stubVCSRepo) LocalPath() string          { return "" }
+func (s *stubVCSRepo) Remote() string             { return "" }
+func (s *stubVCSRepo) UpdateVersion(string) error { return nil }
+func (s *stubVCSRepo) Version() (string, error)   { return "", nil }
+func (s *stubVCSRepo) IsReference(string) bool    { return false }
+func (s *stubVCSRepo) IsDirty() bool              { return false }
+func (s *stubVCSRepo) Tags() ([]string, error)    { return nil, nil }
+func (s *stubVCSRepo) Update() error              { return nil }
+func (s *stubVCSRepo) Vcs() vcs.Type              { return s.vcsType }
+
+func (s *stubVCSRepo) RunFromDir(cmd string, args ...string) ([]byte, error) {
+	key := strings.Join(append([]string{cmd}, args...), " ")
+	if err, ok := s.errs[key]; ok {
+		return nil, err
+	}
+	return []byte(s.commands[key]), nil
+}
+
+func TestCommitsBehindUsesResolvedDefaultBranchNotUpstream(t *testing.T) {
+	repo := &stubVCSRepo{
+		vcsType: vcs.Git,
+		commands: map[string]string{
+			"git symbolic-ref refs/remotes/origin/HEAD": "refs/remotes/origin/main\n",
+			"git rev-list --count HEAD..origin/main":    "3\n",
+		},
+	}
+
+	if got := commitsBehind(repo); got != 3 {
+		t.Fatalf("expected 3 commits behind, got %d", got)
+	}
+}
+
+func TestCommitsBehindFallsBackToRemoteShowWhenSymrefMissing(t *testing.T) {
+	repo := &stubVCSRepo{
+		vcsType: vcs.Git,
+		commands: map[string]string{
+			"git remote show origin":                    "* remote origin\n  HEAD branch: develop\n",
+			"git rev-list --count HEAD..origin/develop": "5\n",
+		},
+		errs: map[string]error{
+			"git symbolic-ref refs/remotes/origin/HEAD": errors.New("not a symbolic ref"),
+		},
+	}
+
+	if got := commitsBehind(repo); got != 5 {
+		t.Fatalf("expected 5 commits behind via the remote show fallback, got %d", got)
+	}
+}
+
+func TestCommitsBehindReportsUnknownForNonGitRepos(t *testing.T) {
+	repo := &stubVCSRepo{vcsType: vcs.Hg}
+	if got := commitsBehind(repo); got != -1 {
+		t.Fatalf("expected -1 for a non-git repo, got %d", got)
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"github.com/Masterminds/vcs"
+	"github.com/whitecypher/vgo/resolver"
+)
+
+// RepoRoot returns the import path of the repository root this Pkg
+// resolves to, as determined by resolveRepoRoot.
+func (p *Pkg) RepoRoot() string {
+	root, _, err := p.resolveRepoRoot()
+	if err != nil {
+		return p.Name
+	}
+	return root.Root
+}
+
+// collectVersionNodes flattens the dependency graph rooted at p into the
+// resolver.Nodes it contributes, one per Pkg in the graph.
+func collectVersionNodes(p *Pkg, nodes []resolver.Node) []resolver.Node {
+	for _, dep := range p.Dependencies {
+		repo, err := dep.VCS()
+		var repoAdapter resolver.Repo
+		if err == nil {
+			repoAdapter = repo
+		}
+		nodes = append(nodes, resolver.Node{
+			RepoRoot:   dep.RepoRoot(),
+			Constraint: string(dep.Version),
+			Reference:  dep.Reference,
+			Parent:     p.Name,
+			Repo:       repoAdapter,
+		})
+		nodes = collectVersionNodes(dep, nodes)
+	}
+	return nodes
+}
+
+// ResolveVersions runs the semver solver over the full dependency graph
+// rooted at p, checks out the resolved tag for each repository, and writes
+// the chosen tags back into the owning Pkgs' Reference field so
+// SaveManifest produces a reproducible lock. Pinned References bypass the
+// solver but are still recorded unchanged.
+func (p *Pkg) ResolveVersions() error {
+	nodes := collectVersionNodes(p, nil)
+	// A repo root that fails to resolve (e.g. a genuine broken diamond)
+	// doesn't stop the rest of the graph from resolving: resolver.Resolve
+	// returns every resolution it managed alongside a MultiError for the
+	// ones it didn't, so those still get applied below.
+	resolutions, err := resolver.Resolve(nodes)
+
+	byRoot := map[string]string{}
+	for _, r := range resolutions {
+		byRoot[r.RepoRoot] = r.Version
+	}
+
+	if applyErr := applyVersionResolutions(p, byRoot); applyErr != nil {
+		return applyErr
+	}
+	return err
+}
+
+// resolveVersion solves constraint down to a concrete tag for p's
+// repository, using only the tags visible on repo. It does not reconcile
+// constraints contributed by other dependents of the same repo root; that
+// cross-graph reconciliation happens in ResolveVersions.
+func (p *Pkg) resolveVersion(repo vcs.Repo, constraint string) (string, error) {
+	node := resolver.Node{
+		RepoRoot:   p.RepoRoot(),
+		Constraint: constraint,
+		Parent:     p.Name,
+		Repo:       repo,
+	}
+	resolutions, err := resolver.Resolve([]resolver.Node{node})
+	if err != nil {
+		return "", err
+	}
+	if len(resolutions) == 0 {
+		return "", nil
+	}
+	return resolutions[0].Version, nil
+}
+
+func applyVersionResolutions(p *Pkg, byRoot map[string]string) error {
+	for _, dep := range p.Dependencies {
+		if version, ok := byRoot[dep.RepoRoot()]; ok {
+			repo, err := dep.VCS()
+			if err != nil {
+				return err
+			}
+			if err := repo.UpdateVersion(version); err != nil {
+				Logf("Checkout failed for %s with error %s", dep.Name, err.Error())
+				return err
+			}
+			dep.Lock()
+			dep.Reference = version
+			dep.Unlock()
+		}
+		if err := applyVersionResolutions(dep, byRoot); err != nil {
+			return err
+		}
+	}
+	return nil
+}